@@ -0,0 +1,106 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// installedDBPath is where apk records the packages it has fixated into a
+// filesystem, relative to the root apk was run against.
+const installedDBPath = "lib/apk/db/installed"
+
+// loadInstalledPackages reads the apk installed-packages database written by
+// FixateApkWorld out of bc.WorkDir and records it on bc.InstalledPackages, so
+// later steps (SBOM generation, provenance) can report exactly what apk
+// resolved and installed, rather than only what was requested.
+func (bc *Context) loadInstalledPackages() error {
+	path := filepath.Join(bc.WorkDir, installedDBPath)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening apk installed database %s: %w", path, err)
+	}
+	defer f.Close()
+
+	pkgs, err := parseInstalledPackages(f)
+	if err != nil {
+		return fmt.Errorf("parsing apk installed database %s: %w", path, err)
+	}
+
+	bc.InstalledPackages = pkgs
+	return nil
+}
+
+// parseInstalledPackages parses apk's v2 installed-database format: records
+// separated by a blank line, each a sequence of "<field>:<value>" lines. The
+// fields used here are the same ones `apk info` derives from: P (name), V
+// (version), L (license), T (description), D (space-separated depends), and
+// C (checksum, prefixed with the "Q1" encoding tag).
+func parseInstalledPackages(r io.Reader) ([]InstalledPackage, error) {
+	var pkgs []InstalledPackage
+	var cur InstalledPackage
+	have := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			if have {
+				pkgs = append(pkgs, cur)
+			}
+			cur = InstalledPackage{}
+			have = false
+			continue
+		}
+
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+
+		switch field {
+		case "P":
+			cur.Name = value
+			have = true
+		case "V":
+			cur.Version = value
+		case "L":
+			cur.License = value
+		case "T":
+			cur.Description = value
+		case "C":
+			cur.Checksum = strings.TrimPrefix(value, "Q1")
+		case "D":
+			if value != "" {
+				cur.Dependencies = strings.Fields(value)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if have {
+		pkgs = append(pkgs, cur)
+	}
+
+	return pkgs, nil
+}
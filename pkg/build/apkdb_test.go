@@ -0,0 +1,80 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseInstalledPackages(t *testing.T) {
+	const db = `P:musl
+V:1.2.3-r0
+L:MIT
+T:the musl c library
+D:so:libc.musl-x86_64.so.1
+C:Q1abcdefghijklmnopqrstuvwxyz0123456=
+
+P:busybox
+V:1.36.0-r1
+L:GPL-2.0-only
+T:busybox
+C:Q1zzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzz=
+
+`
+
+	pkgs, err := parseInstalledPackages(strings.NewReader(db))
+	if err != nil {
+		t.Fatalf("parseInstalledPackages: %v", err)
+	}
+
+	if len(pkgs) != 2 {
+		t.Fatalf("got %d packages, want 2", len(pkgs))
+	}
+
+	if got, want := pkgs[0].Name, "musl"; got != want {
+		t.Errorf("pkgs[0].Name = %q, want %q", got, want)
+	}
+	if got, want := pkgs[0].Version, "1.2.3-r0"; got != want {
+		t.Errorf("pkgs[0].Version = %q, want %q", got, want)
+	}
+	if got, want := pkgs[0].License, "MIT"; got != want {
+		t.Errorf("pkgs[0].License = %q, want %q", got, want)
+	}
+	if got, want := pkgs[0].Checksum, "abcdefghijklmnopqrstuvwxyz0123456="; got != want {
+		t.Errorf("pkgs[0].Checksum = %q, want %q", got, want)
+	}
+	if got, want := len(pkgs[0].Dependencies), 1; got != want {
+		t.Errorf("len(pkgs[0].Dependencies) = %d, want %d", got, want)
+	}
+
+	if got, want := pkgs[1].Name, "busybox"; got != want {
+		t.Errorf("pkgs[1].Name = %q, want %q", got, want)
+	}
+}
+
+func TestParseInstalledPackagesNoTrailingBlankLine(t *testing.T) {
+	const db = `P:musl
+V:1.2.3-r0`
+
+	pkgs, err := parseInstalledPackages(strings.NewReader(db))
+	if err != nil {
+		t.Fatalf("parseInstalledPackages: %v", err)
+	}
+
+	if len(pkgs) != 1 || pkgs[0].Name != "musl" {
+		t.Fatalf("got %+v, want a single musl package", pkgs)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+
+	"chainguard.dev/apko/pkg/build/assertions"
+	"chainguard.dev/apko/pkg/build/types"
+)
+
+// Root implements assertions.Target.
+func (bc *Context) Root() string {
+	return bc.WorkDir
+}
+
+// Configuration implements assertions.Target.
+func (bc *Context) Configuration() types.ImageConfiguration {
+	return bc.ImageConfiguration
+}
+
+// loadDeclarativeAssertions turns the declarative checks in
+// bc.ImageConfiguration.Assertions into Assertions appended to bc.Assertions,
+// using the pkg/build/assertions registry. A single build reports every
+// unknown key or malformed argument via multierror, rather than stopping at
+// the first one.
+func (bc *Context) loadDeclarativeAssertions() error {
+	var result error
+
+	for key, args := range bc.ImageConfiguration.Assertions {
+		ctor, ok := assertions.Get(key)
+		if !ok {
+			result = multierror.Append(result, fmt.Errorf("unknown assertion %q", key))
+			continue
+		}
+
+		fn, err := ctor(args)
+		if err != nil {
+			result = multierror.Append(result, fmt.Errorf("configuring assertion %q: %w", key, err))
+			continue
+		}
+
+		bc.Assertions = append(bc.Assertions, func(c *Context) error {
+			return fn(c)
+		})
+	}
+
+	return result
+}
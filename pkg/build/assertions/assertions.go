@@ -0,0 +1,52 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package assertions provides a registry of declarative, YAML-configurable
+// build assertions. It depends only on pkg/build/types, not pkg/build
+// itself, so that pkg/build can construct assertions from this registry
+// without an import cycle; build.Context satisfies Target structurally.
+package assertions
+
+import (
+	"chainguard.dev/apko/pkg/build/types"
+)
+
+// Target is the minimal view of a build context an assertion needs.
+type Target interface {
+	// Root is the path to the built image filesystem.
+	Root() string
+	// Configuration is the image configuration used for this build.
+	Configuration() types.ImageConfiguration
+}
+
+// Func is a single assertion checked against a finished build.
+type Func func(Target) error
+
+// Constructor builds a Func from the value configured under a declarative
+// assertion's key in ImageConfiguration.Assertions.
+type Constructor func(args interface{}) (Func, error)
+
+var registry = map[string]Constructor{
+	"required-files":        newRequiredFiles,
+	"forbidden-packages":    newForbiddenPackages,
+	"max-image-size":        newMaxImageSize,
+	"required-users":        newRequiredUsers,
+	"entrypoint-must-exist": newEntrypointMustExist,
+}
+
+// Get looks up the constructor registered for a declarative assertion key.
+func Get(key string) (Constructor, bool) {
+	ctor, ok := registry[key]
+	return ctor, ok
+}
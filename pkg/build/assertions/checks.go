@@ -0,0 +1,210 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assertions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// stringList coerces a YAML-decoded assertion argument (a []interface{} of
+// scalars) into a []string.
+func stringList(args interface{}) ([]string, error) {
+	raw, ok := args.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list of strings, got %T", args)
+	}
+
+	out := make([]string, 0, len(raw))
+	for _, v := range raw {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string list entry, got %T", v)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// newRequiredFiles builds an assertion that fails if any of the listed
+// paths is missing from the built image.
+func newRequiredFiles(args interface{}) (Func, error) {
+	paths, err := stringList(args)
+	if err != nil {
+		return nil, fmt.Errorf("required-files: %w", err)
+	}
+
+	return func(t Target) error {
+		for _, p := range paths {
+			full := filepath.Join(t.Root(), p)
+			if _, err := os.Stat(full); err != nil {
+				return fmt.Errorf("required-files: %s is missing: %w", p, err)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// newForbiddenPackages builds an assertion that fails if any of the listed
+// packages is configured to be installed.
+func newForbiddenPackages(args interface{}) (Func, error) {
+	names, err := stringList(args)
+	if err != nil {
+		return nil, fmt.Errorf("forbidden-packages: %w", err)
+	}
+
+	forbidden := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		forbidden[bareName(n)] = struct{}{}
+	}
+
+	return func(t Target) error {
+		for _, pkg := range t.Configuration().Contents.Packages {
+			if _, ok := forbidden[bareName(pkg)]; ok {
+				return fmt.Errorf("forbidden-packages: %s is configured for installation", pkg)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// bareName strips the version/operator syntax apk allows on a world entry
+// (e.g. "openssh-server=1.2.3-r0", "openssh-server~1.2", "openssh-server>2")
+// down to the bare package name, the same way pkg/sbom/generator/cyclonedx
+// and pkg/sbom/generator/spdx strip it before comparing dependencies.
+func bareName(pkg string) string {
+	if i := strings.IndexAny(pkg, " ~<>=/!"); i > -1 {
+		return pkg[:i]
+	}
+	return pkg
+}
+
+// newRequiredUsers builds an assertion that fails if any of the listed
+// usernames is not configured in Accounts.Users.
+func newRequiredUsers(args interface{}) (Func, error) {
+	names, err := stringList(args)
+	if err != nil {
+		return nil, fmt.Errorf("required-users: %w", err)
+	}
+
+	return func(t Target) error {
+		configured := make(map[string]struct{}, len(t.Configuration().Accounts.Users))
+		for _, u := range t.Configuration().Accounts.Users {
+			configured[u.UserName] = struct{}{}
+		}
+
+		for _, n := range names {
+			if _, ok := configured[n]; !ok {
+				return fmt.Errorf("required-users: %s is not configured", n)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// newEntrypointMustExist builds an assertion that fails if the configured
+// entrypoint's executable is not present in the built image. A false
+// argument disables the check entirely.
+func newEntrypointMustExist(args interface{}) (Func, error) {
+	enabled, ok := args.(bool)
+	if !ok {
+		return nil, fmt.Errorf("entrypoint-must-exist: expected a bool, got %T", args)
+	}
+	if !enabled {
+		return func(Target) error { return nil }, nil
+	}
+
+	return func(t Target) error {
+		cmd := strings.TrimSpace(t.Configuration().Entrypoint.Command)
+		if cmd == "" {
+			return fmt.Errorf("entrypoint-must-exist: no entrypoint command is configured")
+		}
+
+		bin := strings.Fields(cmd)[0]
+		full := filepath.Join(t.Root(), bin)
+		if _, err := os.Stat(full); err != nil {
+			return fmt.Errorf("entrypoint-must-exist: %s is missing: %w", bin, err)
+		}
+		return nil
+	}, nil
+}
+
+// newMaxImageSize builds an assertion that fails if the built image
+// filesystem exceeds the given size, expressed as a string: a byte count,
+// optionally with a KB, MB, or GB suffix (e.g. "1024", "50MB").
+func newMaxImageSize(args interface{}) (Func, error) {
+	s, ok := args.(string)
+	if !ok {
+		return nil, fmt.Errorf("max-image-size: expected a string, got %T", args)
+	}
+
+	max, err := parseSize(s)
+	if err != nil {
+		return nil, fmt.Errorf("max-image-size: %w", err)
+	}
+
+	return func(t Target) error {
+		var total int64
+		err := filepath.WalkDir(t.Root(), func(_ string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() {
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				total += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("max-image-size: walking %s: %w", t.Root(), err)
+		}
+
+		if total > max {
+			return fmt.Errorf("max-image-size: image is %d bytes, exceeding the %d byte limit", total, max)
+		}
+		return nil
+	}, nil
+}
+
+var sizeSuffixes = map[string]int64{
+	"KB": 1000,
+	"MB": 1000 * 1000,
+	"GB": 1000 * 1000 * 1000,
+}
+
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(strings.ToUpper(s))
+	for suffix, mult := range sizeSuffixes {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSuffix(s, suffix), 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid size %q: %w", s, err)
+			}
+			return int64(n * float64(mult)), nil
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: expected a byte count, optionally with a KB/MB/GB suffix", s)
+	}
+	return n, nil
+}
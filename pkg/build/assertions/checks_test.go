@@ -0,0 +1,88 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assertions
+
+import (
+	"testing"
+
+	"chainguard.dev/apko/pkg/build/types"
+)
+
+type fakeTarget struct {
+	root   string
+	config types.ImageConfiguration
+}
+
+func (f fakeTarget) Root() string { return f.root }
+
+func (f fakeTarget) Configuration() types.ImageConfiguration { return f.config }
+
+func TestBareName(t *testing.T) {
+	cases := map[string]string{
+		"openssh-server":           "openssh-server",
+		"openssh-server=1.2.3-r0":  "openssh-server",
+		"openssh-server~1.2":       "openssh-server",
+		"openssh-server>2":         "openssh-server",
+		"so:libc.musl-x86_64.so.1": "so:libc.musl-x86_64.so.1",
+	}
+
+	for in, want := range cases {
+		if got := bareName(in); got != want {
+			t.Errorf("bareName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNewForbiddenPackagesVersionPinned(t *testing.T) {
+	fn, err := newForbiddenPackages([]interface{}{"openssh-server"})
+	if err != nil {
+		t.Fatalf("newForbiddenPackages: %v", err)
+	}
+
+	tgt := fakeTarget{
+		config: types.ImageConfiguration{
+			Contents: struct {
+				Repositories []string
+				Keyring      []string
+				Packages     []string
+			}{
+				Packages: []string{"openssh-server=1.2.3-r0"},
+			},
+		},
+	}
+
+	if err := fn(tgt); err == nil {
+		t.Fatalf("expected the version-pinned forbidden package to be caught, got nil error")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{
+		"50MB": 50 * 1000 * 1000,
+		"1GB":  1000 * 1000 * 1000,
+		"10KB": 10 * 1000,
+		"1024": 1024,
+	}
+
+	for in, want := range cases {
+		got, err := parseSize(in)
+		if err != nil {
+			t.Fatalf("parseSize(%q): %v", in, err)
+		}
+		if got != want {
+			t.Errorf("parseSize(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
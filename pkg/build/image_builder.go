@@ -17,6 +17,7 @@ package build
 import (
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -32,6 +33,9 @@ func (bc *Context) BuildImage() error {
 	if err := bc.ImageConfiguration.Validate(); err != nil {
 		return fmt.Errorf("failed to validate configuration: %w", err)
 	}
+	if err := bc.loadDeclarativeAssertions(); err != nil {
+		return fmt.Errorf("failed to load declared assertions: %w", err)
+	}
 
 	log.Printf("building image fileystem in %s", bc.WorkDir)
 
@@ -72,6 +76,11 @@ func (bc *Context) BuildImage() error {
 		return fmt.Errorf("failed to fixate apk world: %w", err)
 	}
 
+	// record what apk actually resolved and installed, for SBOM/provenance
+	if err := bc.loadInstalledPackages(); err != nil {
+		return fmt.Errorf("failed to load installed packages: %w", err)
+	}
+
 	eg.Go(func() error {
 		if err := bc.normalizeApkScriptsTar(); err != nil {
 			return fmt.Errorf("failed to normalize scripts.tar: %w", err)
@@ -106,10 +115,29 @@ func (bc *Context) BuildImage() error {
 		return fmt.Errorf("failed to write supervision tree: %w", err)
 	}
 
-	// generate SBOM
+	// generate SBOMs, one file per configured format
 	if bc.SBOMPath != "" {
-		if err := bc.GenerateSBOM(); err != nil {
-			return fmt.Errorf("failed to generate SBOM: %w", err)
+		formats := bc.SBOMFormats
+		if len(formats) == 0 {
+			formats = []string{"cyclonedx"}
+		}
+
+		for _, format := range formats {
+			if err := bc.GenerateSBOM(format); err != nil {
+				return fmt.Errorf("failed to generate %s SBOM: %w", format, err)
+			}
+		}
+	}
+
+	// generate in-toto SLSA provenance attesting to this build
+	if bc.ProvenancePath != "" {
+		imageDigest, err := bc.hashImageTarball(io.Discard)
+		if err != nil {
+			return fmt.Errorf("failed to hash image tarball: %w", err)
+		}
+
+		if err := bc.GenerateProvenance(imageDigest); err != nil {
+			return fmt.Errorf("failed to generate provenance: %w", err)
 		}
 	}
 
@@ -0,0 +1,232 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	ggcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"golang.org/x/sync/errgroup"
+
+	"chainguard.dev/apko/pkg/build/types"
+)
+
+// MultiArchBuilder builds one image for each architecture declared in a
+// shared ImageConfiguration, each under its own sibling WorkDir, and
+// aggregates the results into a single OCI image index.
+type MultiArchBuilder struct {
+	// Template is copied to produce the per-arch Context used for each
+	// build. WorkDir, Arch, and ImageConfiguration.Archs are always
+	// overridden per architecture; SBOMPath and ProvenancePath are file
+	// paths, so buildArch also re-roots them under each arch's own WorkDir
+	// rather than leaving every arch writing to the same file. Everything
+	// else (SBOMFormats, Assertions, ...) is shared verbatim.
+	Template Context
+
+	// BaseWorkDir is the parent directory under which a sibling WorkDir is
+	// created for each architecture, at BaseWorkDir/<arch>.
+	BaseWorkDir string
+
+	// Parallelism bounds how many per-arch builds run concurrently. A value
+	// <= 0 means unbounded.
+	Parallelism int
+}
+
+// ArchResult captures the outcome of building a single architecture, so a
+// single failing architecture doesn't keep the others from reporting their
+// own logs and results.
+type ArchResult struct {
+	Arch    types.Architecture
+	Context *Context
+	Err     error
+}
+
+// Build builds every architecture in ImageConfiguration.Archs concurrently,
+// bounded by Parallelism, and returns one ArchResult per architecture in the
+// same order.
+//
+// NOT IMPLEMENTED: sharing a single resolved apk index fetch across arches,
+// one of the two things this backlog item asked for, is not done here or
+// anywhere else in this codebase. Each per-arch Context still resolves its
+// own apk index independently, with no deduplication of the fetch. Doing
+// this properly requires a shared fetcher/cache to be threaded into
+// InitApkRepositories/FixateApkWorld, which live outside this file; building
+// that here would mean duplicating apk's index-fetching logic rather than
+// reusing it. Treat multi-arch builds as N independent index fetches until
+// that lands.
+func (b *MultiArchBuilder) Build() ([]ArchResult, error) {
+	archs := b.Template.ImageConfiguration.Archs
+	if len(archs) == 0 {
+		archs = types.AllArchs
+	}
+
+	results := make([]ArchResult, len(archs))
+
+	var eg errgroup.Group
+	if b.Parallelism > 0 {
+		eg.SetLimit(b.Parallelism)
+	}
+
+	for i, arch := range archs {
+		i, arch := i, arch
+		eg.Go(func() error {
+			bc, err := b.buildArch(arch)
+			if err != nil {
+				log.Printf("building %s failed: %v", arch, err)
+			}
+			results[i] = ArchResult{Arch: arch, Context: bc, Err: err}
+			return nil
+		})
+	}
+
+	// Errors are carried per-architecture in results, not returned here:
+	// every goroutine above returns nil, so eg.Wait reports no error.
+	if err := eg.Wait(); err != nil {
+		return results, err
+	}
+
+	return results, nil
+}
+
+func (b *MultiArchBuilder) buildArch(arch types.Architecture) (*Context, error) {
+	bc := b.Template
+	bc.Arch = arch
+	bc.WorkDir = filepath.Join(b.BaseWorkDir, string(arch))
+	bc.ImageConfiguration.Archs = []types.Architecture{arch}
+
+	// SBOMPath and ProvenancePath are file paths, not directories: left as
+	// Template set them, every arch would write to the very same path
+	// concurrently. Re-root them under this arch's own WorkDir so each
+	// build produces its own files for GenerateIndexSBOM to reference.
+	if b.Template.SBOMPath != "" {
+		bc.SBOMPath = filepath.Join(bc.WorkDir, filepath.Base(b.Template.SBOMPath))
+	}
+	if b.Template.ProvenancePath != "" {
+		bc.ProvenancePath = filepath.Join(bc.WorkDir, filepath.Base(b.Template.ProvenancePath))
+	}
+
+	if err := bc.BuildImage(); err != nil {
+		return &bc, fmt.Errorf("building %s: %w", arch, err)
+	}
+
+	return &bc, nil
+}
+
+// Index aggregates successful per-arch build results into an OCI image
+// index, tagging each manifest with its Architecture.ToOCIPlatform(). Failed
+// architectures are skipped; images must already be resolved (e.g. via
+// Context.ImageIndex) for each successful ArchResult.
+func (b *MultiArchBuilder) Index(results []ArchResult, images map[types.Architecture]v1.Image) (v1.ImageIndex, error) {
+	idx := mutate.IndexMediaType(empty.Index, ggcrtypes.OCIImageIndex)
+
+	var adds []mutate.IndexAddendum
+	for _, r := range results {
+		if r.Err != nil {
+			continue
+		}
+
+		img, ok := images[r.Arch]
+		if !ok {
+			return nil, fmt.Errorf("no resolved image for architecture %s", r.Arch)
+		}
+
+		adds = append(adds, mutate.IndexAddendum{
+			Add: img,
+			Descriptor: v1.Descriptor{
+				Platform: r.Arch.ToOCIPlatform(),
+			},
+		})
+	}
+
+	return mutate.AppendManifests(idx, adds...), nil
+}
+
+// IndexSBOM is the index-level SBOM: rather than duplicating every
+// architecture's package list, it points at each arch's already-generated
+// SBOM by digest, the same way an OCI image index points at per-arch
+// manifests by digest.
+type IndexSBOM struct {
+	MediaType string        `json:"mediaType"`
+	Manifests []ArchSBOMRef `json:"manifests"`
+}
+
+// ArchSBOMRef references one architecture's SBOM, in one format, by digest.
+type ArchSBOMRef struct {
+	Architecture types.Architecture `json:"architecture"`
+	Format       string             `json:"format"`
+	Path         string             `json:"path"`
+	Digest       string             `json:"digest"`
+}
+
+// GenerateIndexSBOM writes an index-level SBOM to path, referencing the
+// already-generated per-arch SBOMs (one per result, per configured format)
+// by their SHA-256 digest. Architectures that failed to build are skipped.
+func (b *MultiArchBuilder) GenerateIndexSBOM(results []ArchResult, path string) error {
+	formats := b.Template.SBOMFormats
+	if len(formats) == 0 {
+		formats = []string{"cyclonedx"}
+	}
+
+	var refs []ArchSBOMRef
+	for _, r := range results {
+		if r.Err != nil || r.Context == nil || r.Context.SBOMPath == "" {
+			continue
+		}
+
+		for _, format := range formats {
+			gen, err := sbomGeneratorFor(format)
+			if err != nil {
+				return err
+			}
+
+			sbomPath := fmt.Sprintf("%s.%s", r.Context.SBOMPath, gen.Ext())
+			digest, err := fileSHA256(sbomPath)
+			if err != nil {
+				return fmt.Errorf("hashing %s SBOM for %s: %w", format, r.Arch, err)
+			}
+
+			refs = append(refs, ArchSBOMRef{
+				Architecture: r.Arch,
+				Format:       format,
+				Path:         sbomPath,
+				Digest:       digest,
+			})
+		}
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("opening index SBOM path %s for writing: %w", path, err)
+	}
+	defer out.Close()
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(IndexSBOM{
+		MediaType: "application/vnd.apko.sbom-index+json",
+		Manifests: refs,
+	}); err != nil {
+		return fmt.Errorf("encoding index SBOM: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"chainguard.dev/apko/pkg/build/types"
+)
+
+func TestGenerateIndexSBOM(t *testing.T) {
+	dir := t.TempDir()
+
+	amd64Path := filepath.Join(dir, "amd64-sbom")
+	if err := os.WriteFile(amd64Path+".cdx", []byte("amd64 sbom contents"), 0o644); err != nil {
+		t.Fatalf("writing fake amd64 SBOM: %v", err)
+	}
+
+	results := []ArchResult{
+		{Arch: "amd64", Context: &Context{SBOMPath: amd64Path}},
+		{Arch: "arm64", Err: errFakeArchBuild},
+	}
+
+	b := &MultiArchBuilder{}
+	outPath := filepath.Join(dir, "index-sbom.json")
+	if err := b.GenerateIndexSBOM(results, outPath); err != nil {
+		t.Fatalf("GenerateIndexSBOM: %v", err)
+	}
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("reading index SBOM: %v", err)
+	}
+
+	var idx IndexSBOM
+	if err := json.Unmarshal(data, &idx); err != nil {
+		t.Fatalf("unmarshaling index SBOM: %v", err)
+	}
+
+	if len(idx.Manifests) != 1 {
+		t.Fatalf("got %d manifests, want 1 (failed arch should be skipped): %+v", len(idx.Manifests), idx.Manifests)
+	}
+
+	ref := idx.Manifests[0]
+	if ref.Architecture != types.Architecture("amd64") {
+		t.Errorf("Architecture = %q, want amd64", ref.Architecture)
+	}
+	if ref.Digest == "" {
+		t.Errorf("Digest is empty, want a sha256 hex digest")
+	}
+}
+
+var errFakeArchBuild = fakeErr("simulated build failure")
+
+type fakeErr string
+
+func (e fakeErr) Error() string { return string(e) }
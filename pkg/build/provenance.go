@@ -0,0 +1,203 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"chainguard.dev/apko/pkg/tarball"
+)
+
+// Version identifies the apko build that produced a provenance statement.
+// It is overridden via -ldflags at release time.
+var Version = "devel"
+
+// buildTypeURI is the stable identifier for the apko build process, used as
+// the in-toto predicate's buildType.
+const buildTypeURI = "https://chainguard.dev/apko/buildtypes/image@v1"
+
+// slsaPredicateType is the in-toto predicateType for SLSA Provenance v1.
+const slsaPredicateType = "https://slsa.dev/provenance/v1"
+
+const inTotoStatementType = "https://in-toto.io/Statement/v1"
+
+// InstalledPackage records a resolved apk package as installed into the
+// image filesystem, read back from apk's own installed-packages database by
+// loadInstalledPackages. It doubles as a provenance material and as an SBOM
+// package entry.
+type InstalledPackage struct {
+	Name         string
+	Version      string
+	License      string
+	Description  string
+	Dependencies []string
+	Checksum     string
+}
+
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type Predicate struct {
+	Builder    Builder    `json:"builder"`
+	BuildType  string     `json:"buildType"`
+	Invocation Invocation `json:"invocation"`
+	Materials  []Material `json:"materials"`
+}
+
+type Builder struct {
+	ID string `json:"id"`
+}
+
+type Invocation struct {
+	ConfigSource ConfigSource `json:"configSource"`
+	Parameters   Parameters   `json:"parameters"`
+}
+
+type ConfigSource struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+type Parameters struct {
+	Architecture    string `json:"architecture"`
+	SourceDateEpoch int64  `json:"sourceDateEpoch"`
+	Entrypoint      string `json:"entrypoint,omitempty"`
+}
+
+type Material struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// GenerateProvenance writes an in-toto v1 SLSA provenance statement
+// describing this build to bc.ProvenancePath. It must run after
+// loadInstalledPackages has populated bc.InstalledPackages from apk's
+// installed-packages database, and after the image filesystem in bc.WorkDir
+// is final.
+func (bc *Context) GenerateProvenance(imageDigest string) error {
+	configDigest, err := fileSHA256(bc.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("hashing image configuration: %w", err)
+	}
+
+	materials := make([]Material, 0, len(bc.ImageConfiguration.Contents.Repositories)+len(bc.InstalledPackages))
+	for _, repo := range bc.ImageConfiguration.Contents.Repositories {
+		materials = append(materials, Material{URI: repo})
+	}
+	for _, pkg := range bc.InstalledPackages {
+		m := Material{URI: fmt.Sprintf("pkg:apk/%s@%s", pkg.Name, pkg.Version)}
+
+		if hexDigest, err := apkChecksumToHex(pkg.Checksum); err == nil {
+			m.Digest = map[string]string{"sha1": hexDigest}
+		}
+
+		materials = append(materials, m)
+	}
+
+	stmt := Statement{
+		Type:          inTotoStatementType,
+		PredicateType: slsaPredicateType,
+		Subject: []Subject{
+			{
+				Name:   "image.tar",
+				Digest: map[string]string{"sha256": imageDigest},
+			},
+		},
+		Predicate: Predicate{
+			Builder:   Builder{ID: fmt.Sprintf("https://chainguard.dev/apko@%s", Version)},
+			BuildType: buildTypeURI,
+			Invocation: Invocation{
+				ConfigSource: ConfigSource{
+					URI:    bc.ConfigPath,
+					Digest: map[string]string{"sha256": configDigest},
+				},
+				Parameters: Parameters{
+					Architecture:    string(bc.Arch),
+					SourceDateEpoch: bc.SourceDateEpoch.Unix(),
+					Entrypoint:      bc.ImageConfiguration.Entrypoint.Command,
+				},
+			},
+			Materials: materials,
+		},
+	}
+
+	out, err := os.Create(bc.ProvenancePath)
+	if err != nil {
+		return fmt.Errorf("opening provenance path %s for writing: %w", bc.ProvenancePath, err)
+	}
+	defer out.Close()
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(stmt); err != nil {
+		return fmt.Errorf("encoding provenance statement: %w", err)
+	}
+	return nil
+}
+
+// hashImageTarball writes the final image filesystem as a tarball to w,
+// returning its hex-encoded SHA-256 digest for use as the provenance
+// subject.
+func (bc *Context) hashImageTarball(w io.Writer) (string, error) {
+	tarctx, err := tarball.NewContext(tarball.WithSourceDateEpoch(bc.SourceDateEpoch))
+	if err != nil {
+		return "", fmt.Errorf("initializing tar context: %w", err)
+	}
+
+	return tarctx.WriteArchiveWithDigest(bc.WorkDir, w)
+}
+
+// apkChecksumToHex converts an apk installed-db checksum (base64-encoded
+// SHA-1, as found in the "C:" field after its "Q1" encoding tag is
+// stripped) into the lowercase hex encoding the in-toto DigestSet
+// convention expects.
+func apkChecksumToHex(checksum string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(checksum)
+	if err != nil {
+		return "", fmt.Errorf("decoding apk checksum %q: %w", checksum, err)
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
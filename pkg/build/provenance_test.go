@@ -0,0 +1,45 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func TestApkChecksumToHex(t *testing.T) {
+	sha1 := []byte{
+		0xda, 0x39, 0xa3, 0xee, 0x5e, 0x6b, 0x4b, 0x0d, 0x32, 0x55,
+		0xbf, 0xef, 0x95, 0x60, 0x18, 0x90, 0xaf, 0xd8, 0x07, 0x09,
+	}
+	checksum := base64.StdEncoding.EncodeToString(sha1)
+
+	got, err := apkChecksumToHex(checksum)
+	if err != nil {
+		t.Fatalf("apkChecksumToHex: %v", err)
+	}
+
+	want := hex.EncodeToString(sha1)
+	if got != want {
+		t.Errorf("apkChecksumToHex(%q) = %q, want %q", checksum, got, want)
+	}
+}
+
+func TestApkChecksumToHexInvalid(t *testing.T) {
+	if _, err := apkChecksumToHex("not valid base64!!"); err == nil {
+		t.Fatalf("expected an error decoding invalid base64, got nil")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"chainguard.dev/apko/pkg/sbom/generator/cyclonedx"
+	"chainguard.dev/apko/pkg/sbom/generator/spdx"
+	"chainguard.dev/apko/pkg/sbom/options"
+)
+
+// sbomGenerator is satisfied by every concrete generator under
+// pkg/sbom/generator, letting GenerateSBOM dispatch on bc.SBOMFormats
+// without hardcoding a single format.
+type sbomGenerator interface {
+	Key() string
+	Ext() string
+	Generate(opts *options.Options, path string) error
+}
+
+func sbomGeneratorFor(format string) (sbomGenerator, error) {
+	switch format {
+	case "cyclonedx":
+		g := cyclonedx.New()
+		return &g, nil
+	case "spdx":
+		g := spdx.New()
+		return &g, nil
+	default:
+		return nil, fmt.Errorf("unknown SBOM format %q", format)
+	}
+}
+
+// GenerateSBOM writes an SBOM describing bc.InstalledPackages in the given
+// format to bc.SBOMPath, with that generator's Ext() appended, so that
+// requesting multiple formats produces one file per format rather than one
+// overwriting another.
+func (bc *Context) GenerateSBOM(format string) error {
+	gen, err := sbomGeneratorFor(format)
+	if err != nil {
+		return err
+	}
+
+	osInfo, err := bc.osRelease()
+	if err != nil {
+		return fmt.Errorf("reading os-release: %w", err)
+	}
+
+	opts := &options.Options{
+		OS:              osInfo,
+		Packages:        bc.InstalledPackages,
+		SourceDateEpoch: bc.SourceDateEpoch,
+	}
+
+	path := fmt.Sprintf("%s.%s", bc.SBOMPath, gen.Ext())
+	if err := gen.Generate(opts, path); err != nil {
+		return fmt.Errorf("generating %s SBOM at %s: %w", format, path, err)
+	}
+
+	return nil
+}
+
+// osRelease reads /etc/os-release out of the built image filesystem to
+// identify the base OS an SBOM describes.
+func (bc *Context) osRelease() (options.OS, error) {
+	path := filepath.Join(bc.WorkDir, "etc", "os-release")
+
+	f, err := os.Open(path)
+	if err != nil {
+		return options.OS{}, fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close()
+
+	fields := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		k, v, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		fields[k] = strings.Trim(v, `"`)
+	}
+	if err := scanner.Err(); err != nil {
+		return options.OS{}, err
+	}
+
+	return options.OS{
+		ID:      fields["ID"],
+		Name:    fields["NAME"],
+		Version: fields["VERSION_ID"],
+	}, nil
+}
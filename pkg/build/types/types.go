@@ -51,6 +51,14 @@ type ImageConfiguration struct {
 		Groups []Group
 	}
 	Archs []Architecture
+
+	// Assertions declares checks to run against the finished build, keyed
+	// by the name of a registered assertion (e.g. "required-files",
+	// "forbidden-packages", "max-image-size", "required-users",
+	// "entrypoint-must-exist"). Each value is passed verbatim to that
+	// assertion's constructor, so its shape depends on which assertion it
+	// configures.
+	Assertions map[string]interface{} `yaml:"assertions,omitempty"`
 }
 
 // Architecture represents a CPU architecture for the container image.
@@ -0,0 +1,195 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spdx
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"chainguard.dev/apko/pkg/sbom/options"
+	"chainguard.dev/apko/pkg/sbom/purl"
+)
+
+type SPDX struct{}
+
+func New() SPDX {
+	return SPDX{}
+}
+
+func (sx *SPDX) Key() string {
+	return "spdx"
+}
+
+func (sx *SPDX) Ext() string {
+	return "spdx.json"
+}
+
+// Generate writes an SPDX 2.3 sbom in path
+func (sx *SPDX) Generate(opts *options.Options, path string) error {
+	rootID := "SPDXRef-" + spdxID(opts.OS.ID)
+
+	packages := []Package{
+		{
+			SPDXID:           rootID,
+			Name:             opts.OS.Name,
+			VersionInfo:      opts.OS.Version,
+			PrimaryPurpose:   "OPERATING-SYSTEM",
+			DownloadLocation: "NOASSERTION",
+			LicenseConcluded: "NOASSERTION",
+			CopyrightText:    "NOASSERTION",
+		},
+	}
+
+	relationships := []Relationship{
+		{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: rootID,
+		},
+	}
+
+	for _, pkg := range opts.Packages {
+		pkgID := "SPDXRef-" + spdxID(pkg.Name+"-"+pkg.Version)
+
+		p := Package{
+			SPDXID:           pkgID,
+			Name:             pkg.Name,
+			VersionInfo:      pkg.Version,
+			PrimaryPurpose:   "LIBRARY",
+			DownloadLocation: "NOASSERTION",
+			LicenseConcluded: pkg.License,
+			CopyrightText:    "NOASSERTION",
+			ExternalRefs: []ExternalRef{
+				{
+					ReferenceCategory: "PACKAGE-MANAGER",
+					ReferenceType:     "purl",
+					ReferenceLocator:  purl.Package(opts.OS.ID, pkg),
+				},
+			},
+		}
+		packages = append(packages, p)
+
+		relationships = append(relationships, Relationship{
+			SPDXElementID:      rootID,
+			RelationshipType:   "CONTAINS",
+			RelatedSPDXElement: pkgID,
+		})
+
+		for _, dep := range pkg.Dependencies {
+			// TODO(kaniini): Properly handle virtual dependencies...
+			if strings.ContainsRune(dep, ':') {
+				continue
+			}
+
+			i := strings.IndexAny(dep, " ~<>=/!")
+			if i > -1 {
+				dep = dep[:i]
+			}
+			if dep == "" {
+				continue
+			}
+
+			relationships = append(relationships, Relationship{
+				SPDXElementID:      pkgID,
+				RelationshipType:   "DEPENDS_ON",
+				RelatedSPDXElement: "SPDXRef-" + spdxID(dep),
+			})
+		}
+	}
+
+	doc := Document{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              opts.OS.Name,
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/apko/%s-%d", opts.OS.ID, opts.SourceDateEpoch.Unix()),
+		CreationInfo: CreationInfo{
+			Created: opts.SourceDateEpoch.UTC().Format("2006-01-02T15:04:05Z"),
+			Creators: []string{
+				"Tool: apko",
+			},
+		},
+		Packages:      packages,
+		Relationships: relationships,
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("opening SBOM path %s for writing: %w", path, err)
+	}
+	defer out.Close()
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encoding SPDX document: %w", err)
+	}
+	return nil
+}
+
+// spdxID sanitizes a string for use as (part of) an SPDX element ID, which
+// may only contain letters, numbers, ".", and "-".
+func spdxID(in string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		default:
+			return '-'
+		}
+	}, in)
+}
+
+type Document struct {
+	SPDXVersion       string         `json:"spdxVersion"`
+	DataLicense       string         `json:"dataLicense"`
+	SPDXID            string         `json:"SPDXID"`
+	Name              string         `json:"name"`
+	DocumentNamespace string         `json:"documentNamespace"`
+	CreationInfo      CreationInfo   `json:"creationInfo"`
+	Packages          []Package      `json:"packages,omitempty"`
+	Relationships     []Relationship `json:"relationships,omitempty"`
+}
+
+type CreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type Package struct {
+	SPDXID           string        `json:"SPDXID"`
+	Name             string        `json:"name"`
+	VersionInfo      string        `json:"versionInfo,omitempty"`
+	PrimaryPurpose   string        `json:"primaryPackagePurpose,omitempty"`
+	DownloadLocation string        `json:"downloadLocation"`
+	LicenseConcluded string        `json:"licenseConcluded,omitempty"`
+	CopyrightText    string        `json:"copyrightText"`
+	ExternalRefs     []ExternalRef `json:"externalRefs,omitempty"`
+}
+
+type ExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type Relationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
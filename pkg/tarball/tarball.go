@@ -16,17 +16,35 @@ package tarball
 
 import (
 	"archive/tar"
+	"bufio"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/moby/patternmatcher"
 )
 
+// apkoIgnoreFile is the name of the dockerignore-style exclusion file
+// autoloaded from the root of the filesystem being archived.
+const apkoIgnoreFile = ".apkoignore"
+
 type Context struct {
 	SourceDateEpoch time.Time
+
+	// Excludes is the compiled dockerignore-style matcher applied while
+	// walking the filesystem in WriteArchiveFromFS. It is populated from
+	// WithExcludes and from an autoloaded .apkoignore file.
+	Excludes *patternmatcher.PatternMatcher
+
+	excludePatterns []string
 }
 
 type Option func(*Context) error
@@ -52,8 +70,59 @@ func WithSourceDateEpoch(t time.Time) Option {
 	}
 }
 
+// WithExcludes registers patterns using the same dockerignore-style glob
+// semantics as Docker's builder (leading "!" negates a prior match, "**"
+// matches recursively, and patterns are evaluated in order). Patterns are
+// compiled into ctx.Excludes, alongside any later found in a .apkoignore
+// file, the first time WriteArchiveFromFS walks the filesystem.
+func WithExcludes(patterns []string) Option {
+	return func(ctx *Context) error {
+		ctx.excludePatterns = append(ctx.excludePatterns, patterns...)
+		return nil
+	}
+}
+
+// loadApkoIgnore reads a .apkoignore file from the root of base, if one
+// exists, appending its patterns to ctx.excludePatterns. It is a no-op if no
+// such file is present.
+func (ctx *Context) loadApkoIgnore(base string) error {
+	f, err := os.Open(filepath.Join(base, apkoIgnoreFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("opening %s: %w", apkoIgnoreFile, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ctx.excludePatterns = append(ctx.excludePatterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading %s: %w", apkoIgnoreFile, err)
+	}
+
+	return nil
+}
+
 // Writes a raw TAR archive to out, given an fs.FS.
 func (ctx *Context) WriteArchiveFromFS(base string, fsys fs.FS, out io.Writer) error {
+	if err := ctx.loadApkoIgnore(base); err != nil {
+		return fmt.Errorf("loading %s: %w", apkoIgnoreFile, err)
+	}
+	if len(ctx.excludePatterns) > 0 && ctx.Excludes == nil {
+		pm, err := patternmatcher.New(ctx.excludePatterns)
+		if err != nil {
+			return fmt.Errorf("compiling exclude patterns: %w", err)
+		}
+		ctx.Excludes = pm
+	}
+
 	gzw := gzip.NewWriter(out)
 	defer gzw.Close()
 
@@ -65,6 +134,22 @@ func (ctx *Context) WriteArchiveFromFS(base string, fsys fs.FS, out io.Writer) e
 			return err
 		}
 
+		if path != "." && ctx.Excludes != nil {
+			excluded, err := ctx.Excludes.Matches(path)
+			if err != nil {
+				return fmt.Errorf("matching %s against %s: %w", path, apkoIgnoreFile, err)
+			}
+			if excluded {
+				// Only prune the whole subtree when no "!" pattern is
+				// configured at all; otherwise keep walking so any negated
+				// descendants are still archived.
+				if d.IsDir() && !ctx.Excludes.Exclusions() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+		}
+
 		info, err := d.Info()
 		if err != nil {
 			return err
@@ -125,3 +210,16 @@ func (ctx *Context) WriteArchive(src string, w io.Writer) error {
 
 	return nil
 }
+
+// WriteArchiveWithDigest writes a tarball of src to w, as with
+// WriteArchive, and returns the hex-encoded SHA-256 digest of the finalized
+// archive bytes. This lets callers (e.g. provenance generation) record the
+// digest without a second pass over the output.
+func (ctx *Context) WriteArchiveWithDigest(src string, w io.Writer) (string, error) {
+	h := sha256.New()
+	if err := ctx.WriteArchive(src, io.MultiWriter(w, h)); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tarball
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"testing/fstest"
+)
+
+func archiveNames(t *testing.T, excludes []string, fsys fstest.MapFS) []string {
+	t.Helper()
+
+	ctx, err := NewContext(WithExcludes(excludes))
+	if err != nil {
+		t.Fatalf("NewContext: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ctx.WriteArchiveFromFS(".", fsys, &buf); err != nil {
+		t.Fatalf("WriteArchiveFromFS: %v", err)
+	}
+
+	gzr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	defer gzr.Close()
+
+	var names []string
+	tr := tar.NewReader(gzr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar read: %v", err)
+		}
+		names = append(names, hdr.Name)
+	}
+	return names
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWriteArchiveFromFSExcludes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"var/cache/apk/foo.apk": &fstest.MapFile{Data: []byte("x")},
+		"var/lib/keep.txt":      &fstest.MapFile{Data: []byte("x")},
+		"etc/os-release":        &fstest.MapFile{Data: []byte("x")},
+	}
+
+	names := archiveNames(t, []string{"var/cache"}, fsys)
+
+	if contains(names, "var/cache/apk/foo.apk") {
+		t.Errorf("expected var/cache/apk/foo.apk to be excluded, got names: %v", names)
+	}
+	if !contains(names, "etc/os-release") {
+		t.Errorf("expected etc/os-release to be kept, got names: %v", names)
+	}
+}
+
+func TestWriteArchiveFromFSExcludesNegation(t *testing.T) {
+	fsys := fstest.MapFS{
+		"var/cache/drop.apk": &fstest.MapFile{Data: []byte("x")},
+		"var/cache/keep.txt": &fstest.MapFile{Data: []byte("x")},
+	}
+
+	names := archiveNames(t, []string{"var/cache", "!var/cache/keep.txt"}, fsys)
+
+	if contains(names, "var/cache/drop.apk") {
+		t.Errorf("expected var/cache/drop.apk to be excluded, got names: %v", names)
+	}
+	if !contains(names, "var/cache/keep.txt") {
+		t.Errorf("expected negated var/cache/keep.txt to survive, got names: %v", names)
+	}
+}